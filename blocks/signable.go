@@ -0,0 +1,49 @@
+package blocks
+
+import (
+	"fmt"
+	"io"
+
+	. "github.com/tendermint/tendermint/binary"
+)
+
+// Signable is implemented by any object that carries a single Signature.
+// WriteSignBytes writes the bytes the Signature covers; they never include
+// the Signature itself.
+type Signable interface {
+	WriteSignBytes(w io.Writer, n *int64, err *error)
+	GetSignature() Signature
+	SetSignature(sig Signature)
+}
+
+// Signature identifies the signer by Address, carries a Sequence for
+// replay protection, and the raw signature Bytes produced by the signer's
+// PrivKey.
+type Signature struct {
+	Address  []byte
+	Sequence uint
+	Bytes    []byte
+}
+
+func ReadSignature(r io.Reader, n *int64, err *error) Signature {
+	return Signature{
+		Address:  ReadByteSlice(r, n, err),
+		Sequence: ReadUVarInt(r, n, err),
+		Bytes:    ReadByteSlice(r, n, err),
+	}
+}
+
+func (sig Signature) WriteTo(w io.Writer) (n int64, err error) {
+	WriteByteSlice(w, sig.Address, &n, &err)
+	WriteUVarInt(w, sig.Sequence, &n, &err)
+	WriteByteSlice(w, sig.Bytes, &n, &err)
+	return
+}
+
+func (sig Signature) IsZero() bool {
+	return len(sig.Bytes) == 0
+}
+
+func (sig Signature) String() string {
+	return fmt.Sprintf("Signature{%X:%v %X}", sig.Address, sig.Sequence, sig.Bytes)
+}
@@ -1,12 +1,12 @@
 package state
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 
-	"github.com/tendermint/go-ed25519"
 	. "github.com/tendermint/tendermint/binary"
 	. "github.com/tendermint/tendermint/blocks"
 	. "github.com/tendermint/tendermint/common"
@@ -19,50 +19,68 @@ const (
 	AccountStatusDupedOut  = byte(0x03)
 )
 
+// AddressLength is the number of bytes of the PubKey hash used as an
+// account's identity.
+const AddressLength = 20
+
 type Account struct {
-	Id     uint64 // Numeric id of account, incrementing.
-	PubKey []byte
+	PubKey PubKey
 }
 
 func ReadAccount(r io.Reader, n *int64, err *error) Account {
 	return Account{
-		Id:     ReadUInt64(r, n, err),
-		PubKey: ReadByteSlice(r, n, err),
+		PubKey: ReadPubKey(r, n, err),
 	}
 }
 
 func (account Account) WriteTo(w io.Writer) (n int64, err error) {
-	WriteUInt64(w, account.Id, &n, &err)
-	WriteByteSlice(w, account.PubKey, &n, &err)
+	WriteBinary(w, account.PubKey, &n, &err)
 	return
 }
 
+// Address is the account's content-addressed identity: Hash(PubKey)[:20].
+// It is derived rather than assigned, so an account can be referenced
+// before it has ever been seen by state.
+func (account Account) Address() []byte {
+	return Sha256(BinaryBytes(account.PubKey))[:AddressLength]
+}
+
 func (account Account) VerifyBytes(msg []byte, sig Signature) bool {
-	if sig.SignerId != account.Id {
-		panic("account.id doesn't match sig.signerid")
+	if !bytes.Equal(sig.Address, account.Address()) {
+		panic("account.address doesn't match sig.address")
 	}
 	if len(sig.Bytes) == 0 {
 		panic("signature is empty")
 	}
-	v1 := &ed25519.Verify{
-		Message:   msg,
-		PubKey:    account.PubKey,
-		Signature: sig.Bytes,
-	}
-	ok := ed25519.VerifyBatch([]*ed25519.Verify{v1})
-	return ok
+	return account.PubKey.VerifyBytes(msg, sig.Bytes)
 }
 
 func (account Account) Verify(o Signable) bool {
 	sig := o.GetSignature()
-	o.SetSignature(Signature{}) // clear
-	msg := BinaryBytes(o)
-	o.SetSignature(sig) // restore
+	msg := signBytes(o, sig.Sequence)
 	return account.VerifyBytes(msg, sig)
 }
 
+// signBytes returns the bytes that actually get signed: o's own sign bytes
+// (via WriteSignBytes, which never includes o's Signature) followed by
+// sequence. Folding sequence in here, rather than leaving it as a bare
+// field on Signature, means a captured Bytes only verifies at the exact
+// sequence it was produced for, so AccountDetail.VerifySigned's
+// monotonic-counter check can't be defeated by rewriting sig.Sequence.
+func signBytes(o Signable, sequence uint) []byte {
+	var n int64
+	var err error
+	buf := new(bytes.Buffer)
+	o.WriteSignBytes(buf, &n, &err)
+	WriteUVarInt(buf, sequence, &n, &err)
+	if err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
 func (account Account) String() string {
-	return fmt.Sprintf("Account{%v:%X}", account.Id, account.PubKey[:6])
+	return fmt.Sprintf("Account{%X:%v}", account.Address(), account.PubKey)
 }
 
 //-----------------------------------------------------------------------------
@@ -70,7 +88,7 @@ func (account Account) String() string {
 type AccountDetail struct {
 	Account
 	Sequence uint
-	Balance  uint64
+	Coins    Coins
 	Status   byte
 }
 
@@ -78,22 +96,46 @@ func ReadAccountDetail(r io.Reader, n *int64, err *error) *AccountDetail {
 	return &AccountDetail{
 		Account:  ReadAccount(r, n, err),
 		Sequence: ReadUVarInt(r, n, err),
-		Balance:  ReadUInt64(r, n, err),
+		Coins:    ReadCoins(r, n, err),
 		Status:   ReadByte(r, n, err),
 	}
 }
 
+// ReadAccountDetailLegacy reads an AccountDetail in the original wire
+// format, from before multi-asset balances and address-based identity:
+// a numeric account id, a raw (un-type-prefixed) ed25519 pubkey, and a
+// single uint64 Balance. It lifts the balance into Coins under
+// DefaultDenom and discards the numeric id in favor of the derived
+// Address. Used to migrate state recorded by the original account model.
+func ReadAccountDetailLegacy(r io.Reader, n *int64, err *error) *AccountDetail {
+	_ = ReadUInt64(r, n, err) // legacy numeric id, superseded by Account.Address()
+	pubKeyBytes := ReadByteSlice(r, n, err)
+	sequence := ReadUVarInt(r, n, err)
+	balance := ReadUInt64(r, n, err)
+	status := ReadByte(r, n, err)
+	var coins Coins
+	if balance > 0 {
+		coins = Coins{{Denom: DefaultDenom, Amount: balance}}
+	}
+	return &AccountDetail{
+		Account:  Account{PubKey: PubKeyEd25519(pubKeyBytes)},
+		Sequence: sequence,
+		Coins:    coins,
+		Status:   status,
+	}
+}
+
 func (accDet *AccountDetail) WriteTo(w io.Writer) (n int64, err error) {
 	WriteBinary(w, accDet.Account, &n, &err)
 	WriteUVarInt(w, accDet.Sequence, &n, &err)
-	WriteUInt64(w, accDet.Balance, &n, &err)
+	WriteBinary(w, accDet.Coins, &n, &err)
 	WriteByte(w, accDet.Status, &n, &err)
 	return
 }
 
 func (accDet *AccountDetail) String() string {
-	return fmt.Sprintf("AccountDetail{%v:%X Sequence:%v Balance:%v Status:%X}",
-		accDet.Id, accDet.PubKey, accDet.Sequence, accDet.Balance, accDet.Status)
+	return fmt.Sprintf("AccountDetail{%X Sequence:%v Coins:%v Status:%X}",
+		accDet.Address(), accDet.Sequence, accDet.Coins, accDet.Status)
 }
 
 func (accDet *AccountDetail) Copy() *AccountDetail {
@@ -101,6 +143,21 @@ func (accDet *AccountDetail) Copy() *AccountDetail {
 	return &accDetCopy
 }
 
+// VerifySigned checks that o was signed by this account's key and that the
+// signature's Sequence is the next one expected, then advances
+// accDet.Sequence so the signature cannot be replayed.
+func (accDet *AccountDetail) VerifySigned(o Signable) bool {
+	sig := o.GetSignature()
+	if sig.Sequence != accDet.Sequence+1 {
+		return false
+	}
+	if !accDet.Account.Verify(o) {
+		return false
+	}
+	accDet.Sequence = sig.Sequence
+	return true
+}
+
 //-------------------------------------
 
 var AccountDetailCodec = accountDetailCodec{}
@@ -123,24 +180,53 @@ func (abc accountDetailCodec) Compare(o1 interface{}, o2 interface{}) int {
 
 type PrivAccount struct {
 	Account
-	PrivKey []byte
+	PrivKey PrivKey
 }
 
-// Generates a new account with private key.
-// The Account.Id is empty since it isn't in the blockchain.
+// Generates a new ed25519 account with private key.
 func GenPrivAccount() *PrivAccount {
-	privKey := CRandBytes(32)
-	pubKey := ed25519.MakePubKey(privKey)
+	privKey := PrivKeyEd25519(CRandBytes(32))
 	return &PrivAccount{
 		Account: Account{
-			Id:     uint64(0),
-			PubKey: pubKey,
+			PubKey: privKey.PubKey(),
 		},
 		PrivKey: privKey,
 	}
 }
 
-// The Account.Id is empty since it isn't in the blockchain.
+// privAccountJSON is the on-disk shape of a PrivAccount. PubKey and PrivKey
+// are marshaled via their type-prefixed wire encoding (json encodes []byte
+// as base64) since PubKey/PrivKey are interfaces and can't be unmarshaled
+// generically.
+type privAccountJSON struct {
+	PubKey  []byte `json:"pub_key"`
+	PrivKey []byte `json:"priv_key"`
+}
+
+func (pa *PrivAccount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(privAccountJSON{
+		PubKey:  BinaryBytes(pa.PubKey),
+		PrivKey: BinaryBytes(pa.PrivKey),
+	})
+}
+
+func (pa *PrivAccount) UnmarshalJSON(data []byte) error {
+	var aux privAccountJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	var n int64
+	var err error
+	pubKey := ReadPubKey(bytes.NewReader(aux.PubKey), &n, &err)
+	privKey := ReadPrivKey(bytes.NewReader(aux.PrivKey), &n, &err)
+	if err != nil {
+		return err
+	}
+	pa.Account = Account{PubKey: pubKey}
+	pa.PrivKey = privKey
+	return nil
+}
+
 func PrivAccountFromJSON(jsonBlob []byte) (privAccount *PrivAccount) {
 	err := json.Unmarshal(jsonBlob, &privAccount)
 	if err != nil {
@@ -149,7 +235,9 @@ func PrivAccountFromJSON(jsonBlob []byte) (privAccount *PrivAccount) {
 	return
 }
 
-// The Account.Id is empty since it isn't in the blockchain.
+// Deprecated: this reads a plaintext JSON keystore with the raw private
+// key on disk. Use PrivAccountFromEncryptedFile for new keystores, or
+// ConvertPrivAccountFile to migrate an existing plaintext file.
 func PrivAccountFromFile(file string) *PrivAccount {
 	jsonBlob, err := ioutil.ReadFile(file)
 	if err != nil {
@@ -158,20 +246,24 @@ func PrivAccountFromFile(file string) *PrivAccount {
 	return PrivAccountFromJSON(jsonBlob)
 }
 
-func (pa *PrivAccount) SignBytes(msg []byte) Signature {
-	signature := ed25519.SignMessage(msg, pa.PrivKey, pa.PubKey)
-	sig := Signature{
-		SignerId: pa.Id,
-		Bytes:    signature,
+// SignBytes signs o for use at the given sequence number, so that a
+// transaction can be signed (and independently verified) before the
+// account's AccountDetail has ever been seen by state. sequence is signed
+// over along with o, so the resulting Signature only verifies at that
+// exact sequence.
+func (pa *PrivAccount) SignBytes(o Signable, sequence uint) Signature {
+	msg := signBytes(o, sequence)
+	return Signature{
+		Address:  pa.Address(),
+		Sequence: sequence,
+		Bytes:    pa.PrivKey.Sign(msg),
 	}
-	return sig
 }
 
-func (pa *PrivAccount) Sign(o Signable) {
+func (pa *PrivAccount) Sign(o Signable, sequence uint) {
 	if !o.GetSignature().IsZero() {
 		panic("Cannot sign: already signed")
 	}
-	msg := BinaryBytes(o)
-	sig := pa.SignBytes(msg)
+	sig := pa.SignBytes(o, sequence)
 	o.SetSignature(sig)
 }
\ No newline at end of file
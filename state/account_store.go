@@ -0,0 +1,109 @@
+package state
+
+import (
+	"bytes"
+
+	"github.com/tendermint/go-merkle"
+)
+
+// Proof independently verifies an AccountStore.Get result against a
+// RootHash, so a light client can check an account's balance without
+// trusting the full node that served it.
+type Proof = merkle.IAVLProof
+
+// AccountStore keeps AccountDetail records in a Merkle-ized key/value tree
+// keyed by account address.
+type AccountStore struct {
+	tree *merkle.IAVLTree
+}
+
+// NewAccountStore creates an AccountStore backed by db. If root is
+// non-empty, the tree committed at that root is loaded; otherwise the
+// store starts empty.
+func NewAccountStore(db merkle.DB, root []byte) *AccountStore {
+	tree := merkle.NewIAVLTree(AccountDetailCodec, db)
+	if len(root) > 0 {
+		tree.Load(root)
+	}
+	return &AccountStore{tree: tree}
+}
+
+// Get returns the AccountDetail at addr along with a Proof that it is (or,
+// when found is false, that nothing is) present at the store's RootHash.
+func (store *AccountStore) Get(addr []byte) (accDet *AccountDetail, proof Proof, found bool) {
+	value, proof, found := store.tree.GetWithProof(addr)
+	if !found {
+		return nil, proof, false
+	}
+	return value.(*AccountDetail), proof, true
+}
+
+func (store *AccountStore) Set(addr []byte, accDet *AccountDetail) {
+	store.tree.Set(addr, accDet)
+}
+
+// Remove deletes the AccountDetail at addr, if any, and reports whether
+// one was present.
+func (store *AccountStore) Remove(addr []byte) bool {
+	_, removed := store.tree.Remove(addr)
+	return removed
+}
+
+// RootHash returns the Merkle root of the tree's current state, including
+// any Set/Remove calls since the last Commit. Call Commit first if the
+// root needs to reflect only durably-persisted state.
+func (store *AccountStore) RootHash() []byte {
+	return store.tree.Hash()
+}
+
+// Commit persists pending Set/Remove calls to the backing db and returns
+// the new RootHash.
+func (store *AccountStore) Commit() []byte {
+	return store.tree.Save()
+}
+
+// Iterate calls fn for every AccountDetail in address order, stopping
+// early if fn returns true.
+func (store *AccountStore) Iterate(fn func(addr []byte, accDet *AccountDetail) bool) {
+	store.tree.Iterate(func(key []byte, value interface{}) bool {
+		return fn(key, value.(*AccountDetail))
+	})
+}
+
+// IterateRange calls fn for every AccountDetail with address in
+// [start, end), stopping early if fn returns true.
+func (store *AccountStore) IterateRange(start, end []byte, fn func(addr []byte, accDet *AccountDetail) bool) {
+	store.tree.IterateRange(start, end, true, func(key []byte, value interface{}) bool {
+		return fn(key, value.(*AccountDetail))
+	})
+}
+
+// MigrateLegacyAccount decodes one AccountDetail recorded in the original
+// (pre-multi-asset, pre-address-identity) wire format via
+// ReadAccountDetailLegacy and writes it into store under the address
+// derived from its PubKey, returning the migrated AccountDetail.
+func MigrateLegacyAccount(store *AccountStore, legacyBytes []byte) (*AccountDetail, error) {
+	var n int64
+	var err error
+	accDet := ReadAccountDetailLegacy(bytes.NewReader(legacyBytes), &n, &err)
+	if err != nil {
+		return nil, err
+	}
+	store.Set(accDet.Address(), accDet)
+	return accDet, nil
+}
+
+// MigrateLegacyAccountStore decodes every entry in legacyEntries (each the
+// original wire encoding of one AccountDetail) into store via
+// MigrateLegacyAccount and commits the result, returning the new
+// RootHash. This is the entry point a one-time migration tool or chain
+// upgrade handler calls to move a pre-address-identity account dump into
+// the current AccountStore.
+func MigrateLegacyAccountStore(store *AccountStore, legacyEntries [][]byte) ([]byte, error) {
+	for _, entry := range legacyEntries {
+		if _, err := MigrateLegacyAccount(store, entry); err != nil {
+			return nil, err
+		}
+	}
+	return store.Commit(), nil
+}
@@ -0,0 +1,127 @@
+package state
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	. "github.com/tendermint/tendermint/binary"
+)
+
+// DefaultDenom is the denomination assumed for accounts migrated from the
+// legacy single-balance wire format.
+const DefaultDenom = "mint"
+
+// MaxCoinsPerAccount bounds the number of distinct denominations a wire-
+// encoded Coins may declare, so a crafted size prefix (e.g. in a
+// transaction's amount field) can't force a huge allocation or a
+// near-infinite read loop before any coin data is validated.
+const MaxCoinsPerAccount = 1 << 16
+
+// Coin is an amount of a single, named asset type.
+type Coin struct {
+	Denom  string
+	Amount uint64
+}
+
+func (coin Coin) String() string {
+	return fmt.Sprintf("%v%v", coin.Amount, coin.Denom)
+}
+
+//-----------------------------------------------------------------------------
+
+// Coins is a set of Coin, at most one per denomination.
+type Coins []Coin
+
+// ReadCoins reads a wire-encoded Coins, collapsing (by summing) any
+// duplicate denominations, so the "at most one Coin per denomination"
+// invariant holds regardless of what was actually on the wire.
+func ReadCoins(r io.Reader, n *int64, err *error) Coins {
+	size := int(ReadUVarInt(r, n, err))
+	if size > MaxCoinsPerAccount {
+		if *err == nil {
+			*err = fmt.Errorf("Coins size %v exceeds max %v", size, MaxCoinsPerAccount)
+		}
+		return nil
+	}
+	sum := make(map[string]uint64, size)
+	for i := 0; i < size; i++ {
+		denom := ReadString(r, n, err)
+		amount := ReadUInt64(r, n, err)
+		sum[denom] += amount
+	}
+	return coinsFromMap(sum)
+}
+
+func (coins Coins) WriteTo(w io.Writer) (n int64, err error) {
+	WriteUVarInt(w, uint(len(coins)), &n, &err)
+	for _, coin := range coins {
+		WriteString(w, coin.Denom, &n, &err)
+		WriteUInt64(w, coin.Amount, &n, &err)
+	}
+	return
+}
+
+func (coins Coins) String() string {
+	return fmt.Sprintf("%v", []Coin(coins))
+}
+
+// AmountOf returns the amount of denom held, or zero if none is held.
+func (coins Coins) AmountOf(denom string) uint64 {
+	for _, coin := range coins {
+		if coin.Denom == denom {
+			return coin.Amount
+		}
+	}
+	return 0
+}
+
+// HasEnough returns true if coins holds at least amount of denom.
+func (coins Coins) HasEnough(denom string, amount uint64) bool {
+	return coins.AmountOf(denom) >= amount
+}
+
+// Plus returns a new Coins with other's amounts added in.
+func (coins Coins) Plus(other Coins) Coins {
+	sum := map[string]uint64{}
+	for _, coin := range coins {
+		sum[coin.Denom] += coin.Amount
+	}
+	for _, coin := range other {
+		sum[coin.Denom] += coin.Amount
+	}
+	return coinsFromMap(sum)
+}
+
+// Minus returns a new Coins with other's amounts subtracted out.
+// Panics if the result would make any denom go negative.
+func (coins Coins) Minus(other Coins) Coins {
+	sum := map[string]uint64{}
+	for _, coin := range coins {
+		sum[coin.Denom] += coin.Amount
+	}
+	for _, coin := range other {
+		if sum[coin.Denom] < coin.Amount {
+			panic(fmt.Sprintf("insufficient %v to subtract %v", coin.Denom, coin))
+		}
+		sum[coin.Denom] -= coin.Amount
+	}
+	return coinsFromMap(sum)
+}
+
+// coinsFromMap builds a canonical Coins from a denom->amount map: sorted
+// by Denom, so that two equal balances always serialize to the same wire
+// bytes (this is the value type stored in the Merkleized AccountStore, so
+// insertion-order-dependent encoding would make subtree hashes diverge
+// across nodes applying the same update).
+func coinsFromMap(sum map[string]uint64) Coins {
+	coins := make(Coins, 0, len(sum))
+	for denom, amount := range sum {
+		if amount == 0 {
+			continue
+		}
+		coins = append(coins, Coin{Denom: denom, Amount: amount})
+	}
+	sort.Slice(coins, func(i, j int) bool { return coins[i].Denom < coins[j].Denom })
+	return coins
+}
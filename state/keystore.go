@@ -0,0 +1,159 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+
+	. "github.com/tendermint/tendermint/binary"
+	. "github.com/tendermint/tendermint/common"
+)
+
+// Default scrypt parameters: N=2^15, r=8, p=1, the interactive-login values
+// recommended by the golang.org/x/crypto/scrypt documentation. Pass 0 for any of scryptN/R/P to PrivAccountToEncryptedFile
+// to use these.
+const (
+	DefaultScryptN = 1 << 15
+	DefaultScryptR = 8
+	DefaultScryptP = 1
+
+	scryptKeyLength = 32
+	saltLength      = 16
+	nonceLength     = 24
+)
+
+// encryptedPrivAccountJSON is the on-disk format for a passphrase-protected
+// PrivAccount. Address and PubKey are kept in cleartext so wallets can list
+// accounts without decrypting; PrivKey is locked behind scrypt key
+// derivation and NaCl secretbox authenticated encryption.
+type encryptedPrivAccountJSON struct {
+	Address    []byte `json:"address"`
+	PubKey     []byte `json:"pub_key"`
+	ScryptN    int    `json:"scrypt_n"`
+	ScryptR    int    `json:"scrypt_r"`
+	ScryptP    int    `json:"scrypt_p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	CipherText []byte `json:"ciphertext"`
+}
+
+// PrivAccountToEncryptedFile writes pa to path, locking its PrivKey behind
+// passphrase. scryptN, scryptR, and scryptP may each be left at 0 to use
+// DefaultScryptN/R/P.
+func PrivAccountToEncryptedFile(pa *PrivAccount, path string, passphrase []byte, scryptN, scryptR, scryptP int) error {
+	if scryptN == 0 {
+		scryptN = DefaultScryptN
+	}
+	if scryptR == 0 {
+		scryptR = DefaultScryptR
+	}
+	if scryptP == 0 {
+		scryptP = DefaultScryptP
+	}
+
+	salt := CRandBytes(saltLength)
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, scryptKeyLength)
+	if err != nil {
+		return err
+	}
+	var secretKey [32]byte
+	copy(secretKey[:], key)
+
+	var nonce [nonceLength]byte
+	copy(nonce[:], CRandBytes(nonceLength))
+
+	plaintext := BinaryBytes(pa.PrivKey)
+	ciphertext := secretbox.Seal(nil, plaintext, &nonce, &secretKey)
+
+	jsonBlob, err := json.Marshal(encryptedPrivAccountJSON{
+		Address:    pa.Address(),
+		PubKey:     BinaryBytes(pa.PubKey),
+		ScryptN:    scryptN,
+		ScryptR:    scryptR,
+		ScryptP:    scryptP,
+		Salt:       salt,
+		Nonce:      nonce[:],
+		CipherText: ciphertext,
+	})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, jsonBlob, 0600)
+}
+
+// PrivAccountFromEncryptedFile reads and decrypts a keystore file written
+// by PrivAccountToEncryptedFile.
+func PrivAccountFromEncryptedFile(path string, passphrase []byte) (*PrivAccount, error) {
+	jsonBlob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var aux encryptedPrivAccountJSON
+	if err := json.Unmarshal(jsonBlob, &aux); err != nil {
+		return nil, err
+	}
+
+	key, err := scrypt.Key(passphrase, aux.Salt, aux.ScryptN, aux.ScryptR, aux.ScryptP, scryptKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	var secretKey [32]byte
+	copy(secretKey[:], key)
+	var nonce [nonceLength]byte
+	copy(nonce[:], aux.Nonce)
+
+	plaintext, ok := secretbox.Open(nil, aux.CipherText, &nonce, &secretKey)
+	if !ok {
+		return nil, errors.New("state: wrong passphrase or corrupted keystore file")
+	}
+
+	var n int64
+	var rerr error
+	privKey := ReadPrivKey(bytes.NewReader(plaintext), &n, &rerr)
+	if rerr != nil {
+		return nil, rerr
+	}
+	pubKey := ReadPubKey(bytes.NewReader(aux.PubKey), &n, &rerr)
+	if rerr != nil {
+		return nil, rerr
+	}
+
+	// pub_key sits outside the secretbox, so it isn't covered by the MAC
+	// that authenticates PrivKey: check it actually corresponds to the
+	// decrypted key rather than silently loading a mismatched pair.
+	if !bytes.Equal(BinaryBytes(privKey.PubKey()), BinaryBytes(pubKey)) {
+		return nil, errors.New("state: keystore pub_key does not match decrypted priv_key")
+	}
+
+	return &PrivAccount{
+		Account: Account{PubKey: pubKey},
+		PrivKey: privKey,
+	}, nil
+}
+
+// ConvertPrivAccountFile migrates a legacy plaintext keystore at
+// plaintextPath (see PrivAccountFromFile) to an encrypted keystore at
+// encryptedPath, locked with passphrase. Unlike PrivAccountFromFile, a
+// missing or malformed plaintextPath is reported as an error rather than
+// panicking.
+func ConvertPrivAccountFile(plaintextPath, encryptedPath string, passphrase []byte, scryptN, scryptR, scryptP int) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Couldn't read PrivAccount: %v", r)
+		}
+	}()
+	jsonBlob, err := ioutil.ReadFile(plaintextPath)
+	if err != nil {
+		return err
+	}
+	var pa *PrivAccount
+	if err := json.Unmarshal(jsonBlob, &pa); err != nil {
+		return err
+	}
+	return PrivAccountToEncryptedFile(pa, encryptedPath, passphrase, scryptN, scryptR, scryptP)
+}
@@ -0,0 +1,67 @@
+package state
+
+import (
+	"bytes"
+	"fmt"
+
+	. "github.com/tendermint/tendermint/binary"
+)
+
+// MaxMultisigParticipants bounds the bitmap/signature count a single
+// MultiSignature or PubKeyMulti may declare, so a crafted wire blob can't
+// force an arbitrarily large allocation before any signature is checked.
+// No real multisig account needs anywhere near this many participants.
+const MaxMultisigParticipants = 1 << 16
+
+// MultiSignature is the wire format carried inside Signature.Bytes when the
+// signer is a PubKeyMulti: which of the n participants signed (Bitmap), and
+// their individual signatures in bitmap order (Sigs).
+type MultiSignature struct {
+	Bitmap []bool
+	Sigs   [][]byte
+}
+
+func ReadMultiSignature(bz []byte) (MultiSignature, error) {
+	var n int64
+	var err error
+	r := bytes.NewReader(bz)
+	size := int(ReadUVarInt(r, &n, &err))
+	if size > MaxMultisigParticipants {
+		return MultiSignature{}, fmt.Errorf("MultiSignature bitmap size %v exceeds max %v", size, MaxMultisigParticipants)
+	}
+	bitmap := make([]bool, size)
+	for i := range bitmap {
+		bitmap[i] = ReadByte(r, &n, &err) != 0x00
+	}
+	numSigs := int(ReadUVarInt(r, &n, &err))
+	if numSigs > MaxMultisigParticipants {
+		return MultiSignature{}, fmt.Errorf("MultiSignature sig count %v exceeds max %v", numSigs, MaxMultisigParticipants)
+	}
+	sigs := make([][]byte, numSigs)
+	for i := range sigs {
+		sigs[i] = ReadByteSlice(r, &n, &err)
+	}
+	if err != nil {
+		return MultiSignature{}, err
+	}
+	return MultiSignature{Bitmap: bitmap, Sigs: sigs}, nil
+}
+
+func (multiSig MultiSignature) Bytes() []byte {
+	var n int64
+	var err error
+	buf := new(bytes.Buffer)
+	WriteUVarInt(buf, uint(len(multiSig.Bitmap)), &n, &err)
+	for _, participating := range multiSig.Bitmap {
+		if participating {
+			WriteByte(buf, 0x01, &n, &err)
+		} else {
+			WriteByte(buf, 0x00, &n, &err)
+		}
+	}
+	WriteUVarInt(buf, uint(len(multiSig.Sigs)), &n, &err)
+	for _, sig := range multiSig.Sigs {
+		WriteByteSlice(buf, sig, &n, &err)
+	}
+	return buf.Bytes()
+}
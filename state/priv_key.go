@@ -0,0 +1,80 @@
+package state
+
+import (
+	"io"
+
+	"github.com/tendermint/go-ed25519"
+	"github.com/tendermint/go-secp256k1"
+	. "github.com/tendermint/tendermint/binary"
+	. "github.com/tendermint/tendermint/common"
+)
+
+const (
+	PrivKeyTypeEd25519   = byte(0x01)
+	PrivKeyTypeSecp256k1 = byte(0x02)
+)
+
+// PrivKey produces a raw signature over msg, verifiable by the matching
+// PubKey. Like PubKey, it is read and written with a leading type byte.
+type PrivKey interface {
+	Sign(msg []byte) []byte
+	PubKey() PubKey
+	WriteTo(w io.Writer) (n int64, err error)
+}
+
+func ReadPrivKey(r io.Reader, n *int64, err *error) PrivKey {
+	type_ := ReadByte(r, n, err)
+	if *err != nil {
+		return nil
+	}
+	switch type_ {
+	case PrivKeyTypeEd25519:
+		return PrivKeyEd25519(ReadByteSlice(r, n, err))
+	case PrivKeyTypeSecp256k1:
+		return PrivKeySecp256k1(ReadByteSlice(r, n, err))
+	default:
+		Panicf("Unknown PrivKey type %X", type_)
+		return nil
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+type PrivKeyEd25519 []byte
+
+func (privKey PrivKeyEd25519) Sign(msg []byte) []byte {
+	pubKey := ed25519.MakePubKey([]byte(privKey))
+	return ed25519.SignMessage(msg, []byte(privKey), pubKey)
+}
+
+func (privKey PrivKeyEd25519) PubKey() PubKey {
+	return PubKeyEd25519(ed25519.MakePubKey([]byte(privKey)))
+}
+
+func (privKey PrivKeyEd25519) WriteTo(w io.Writer) (n int64, err error) {
+	WriteByte(w, PrivKeyTypeEd25519, &n, &err)
+	WriteByteSlice(w, []byte(privKey), &n, &err)
+	return
+}
+
+//-----------------------------------------------------------------------------
+
+type PrivKeySecp256k1 []byte
+
+func (privKey PrivKeySecp256k1) Sign(msg []byte) []byte {
+	sig, err := secp256k1.Sign(Sha256(msg), []byte(privKey))
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+func (privKey PrivKeySecp256k1) PubKey() PubKey {
+	return PubKeySecp256k1(secp256k1.PubKeyFromPrivKey([]byte(privKey)))
+}
+
+func (privKey PrivKeySecp256k1) WriteTo(w io.Writer) (n int64, err error) {
+	WriteByte(w, PrivKeyTypeSecp256k1, &n, &err)
+	WriteByteSlice(w, []byte(privKey), &n, &err)
+	return
+}
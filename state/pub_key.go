@@ -0,0 +1,175 @@
+package state
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tendermint/go-ed25519"
+	"github.com/tendermint/go-secp256k1"
+	. "github.com/tendermint/tendermint/binary"
+	. "github.com/tendermint/tendermint/common"
+)
+
+const (
+	PubKeyTypeEd25519   = byte(0x01)
+	PubKeyTypeSecp256k1 = byte(0x02)
+	PubKeyTypeMulti     = byte(0x03)
+)
+
+// MaxMultisigDepth bounds how many PubKeyMulti levels may nest inside one
+// another, so a small crafted blob of nested multisig pubkeys can't drive
+// unbounded recursion while parsing or verifying.
+const MaxMultisigDepth = 3
+
+// PubKey verifies a signature produced over msg. Concrete implementations
+// are read and written with a leading type byte so an Account can carry
+// any supported key kind.
+type PubKey interface {
+	VerifyBytes(msg []byte, sig []byte) bool
+	WriteTo(w io.Writer) (n int64, err error)
+	String() string
+}
+
+func ReadPubKey(r io.Reader, n *int64, err *error) PubKey {
+	return readPubKey(r, n, err, 0)
+}
+
+func readPubKey(r io.Reader, n *int64, err *error, depth int) PubKey {
+	type_ := ReadByte(r, n, err)
+	if *err != nil {
+		return nil
+	}
+	switch type_ {
+	case PubKeyTypeEd25519:
+		return PubKeyEd25519(ReadByteSlice(r, n, err))
+	case PubKeyTypeSecp256k1:
+		return PubKeySecp256k1(ReadByteSlice(r, n, err))
+	case PubKeyTypeMulti:
+		if depth >= MaxMultisigDepth {
+			if *err == nil {
+				*err = fmt.Errorf("PubKeyMulti nesting exceeds max depth %v", MaxMultisigDepth)
+			}
+			return nil
+		}
+		return readPubKeyMulti(r, n, err, depth+1)
+	default:
+		Panicf("Unknown PubKey type %X", type_)
+		return nil
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+type PubKeyEd25519 []byte
+
+func (pubKey PubKeyEd25519) VerifyBytes(msg []byte, sig []byte) bool {
+	v := &ed25519.Verify{
+		Message:   msg,
+		PubKey:    []byte(pubKey),
+		Signature: sig,
+	}
+	return ed25519.VerifyBatch([]*ed25519.Verify{v})
+}
+
+func (pubKey PubKeyEd25519) WriteTo(w io.Writer) (n int64, err error) {
+	WriteByte(w, PubKeyTypeEd25519, &n, &err)
+	WriteByteSlice(w, []byte(pubKey), &n, &err)
+	return
+}
+
+func (pubKey PubKeyEd25519) String() string {
+	return fmt.Sprintf("PubKeyEd25519{%X}", []byte(pubKey))
+}
+
+//-----------------------------------------------------------------------------
+
+type PubKeySecp256k1 []byte
+
+func (pubKey PubKeySecp256k1) VerifyBytes(msg []byte, sig []byte) bool {
+	return secp256k1.VerifySignature([]byte(pubKey), Sha256(msg), sig)
+}
+
+func (pubKey PubKeySecp256k1) WriteTo(w io.Writer) (n int64, err error) {
+	WriteByte(w, PubKeyTypeSecp256k1, &n, &err)
+	WriteByteSlice(w, []byte(pubKey), &n, &err)
+	return
+}
+
+func (pubKey PubKeySecp256k1) String() string {
+	return fmt.Sprintf("PubKeySecp256k1{%X}", []byte(pubKey))
+}
+
+//-----------------------------------------------------------------------------
+
+// PubKeyMulti is a k-of-n threshold multisig public key. VerifyBytes
+// accepts a MultiSignature: a bitmap of participating signers plus their
+// individual signatures, and requires at least Threshold of them to verify.
+type PubKeyMulti struct {
+	Threshold uint
+	PubKeys   []PubKey
+}
+
+func readPubKeyMulti(r io.Reader, n *int64, err *error, depth int) PubKeyMulti {
+	threshold := ReadUVarInt(r, n, err)
+	size := int(ReadUVarInt(r, n, err))
+	if size > MaxMultisigParticipants {
+		if *err == nil {
+			*err = fmt.Errorf("PubKeyMulti participant count %v exceeds max %v", size, MaxMultisigParticipants)
+		}
+		return PubKeyMulti{}
+	}
+	pubKeys := make([]PubKey, size)
+	for i := 0; i < size; i++ {
+		pubKeys[i] = readPubKey(r, n, err, depth)
+	}
+	if threshold == 0 || threshold > uint(size) {
+		if *err == nil {
+			*err = fmt.Errorf("PubKeyMulti threshold %v invalid for %v participants", threshold, size)
+		}
+		return PubKeyMulti{}
+	}
+	return PubKeyMulti{Threshold: threshold, PubKeys: pubKeys}
+}
+
+// VerifyBytes requires at least Threshold of the participating
+// sub-signatures in sig to verify. Threshold == 0 (the zero value for an
+// unconstructed PubKeyMulti) is rejected outright rather than being
+// treated as "no signatures required".
+func (pubKey PubKeyMulti) VerifyBytes(msg []byte, sig []byte) bool {
+	if pubKey.Threshold == 0 || pubKey.Threshold > uint(len(pubKey.PubKeys)) {
+		return false
+	}
+	multiSig, err := ReadMultiSignature(sig)
+	if err != nil || len(multiSig.Bitmap) != len(pubKey.PubKeys) {
+		return false
+	}
+	valid := uint(0)
+	sigIndex := 0
+	for i, participating := range multiSig.Bitmap {
+		if !participating {
+			continue
+		}
+		if sigIndex >= len(multiSig.Sigs) {
+			return false
+		}
+		if pubKey.PubKeys[i].VerifyBytes(msg, multiSig.Sigs[sigIndex]) {
+			valid++
+		}
+		sigIndex++
+	}
+	return valid >= pubKey.Threshold
+}
+
+func (pubKey PubKeyMulti) WriteTo(w io.Writer) (n int64, err error) {
+	WriteByte(w, PubKeyTypeMulti, &n, &err)
+	WriteUVarInt(w, pubKey.Threshold, &n, &err)
+	WriteUVarInt(w, uint(len(pubKey.PubKeys)), &n, &err)
+	for _, pk := range pubKey.PubKeys {
+		WriteBinary(w, pk, &n, &err)
+	}
+	return
+}
+
+func (pubKey PubKeyMulti) String() string {
+	return fmt.Sprintf("PubKeyMulti{%v/%v}", pubKey.Threshold, len(pubKey.PubKeys))
+}
@@ -0,0 +1,53 @@
+package state
+
+import (
+	"testing"
+
+	. "github.com/tendermint/tendermint/common"
+)
+
+func TestPubKeyMultiVerifyBytes(t *testing.T) {
+	msg := []byte("hello")
+	privs := []PrivKey{
+		PrivKeyEd25519(CRandBytes(32)),
+		PrivKeyEd25519(CRandBytes(32)),
+		PrivKeyEd25519(CRandBytes(32)),
+	}
+	pubs := make([]PubKey, len(privs))
+	for i, priv := range privs {
+		pubs[i] = priv.PubKey()
+	}
+	multiPub := PubKeyMulti{Threshold: 2, PubKeys: pubs}
+
+	sign := func(participating ...int) []byte {
+		bitmap := make([]bool, len(privs))
+		var sigs [][]byte
+		for _, i := range participating {
+			bitmap[i] = true
+			sigs = append(sigs, privs[i].Sign(msg))
+		}
+		return MultiSignature{Bitmap: bitmap, Sigs: sigs}.Bytes()
+	}
+
+	if !multiPub.VerifyBytes(msg, sign(0, 1)) {
+		t.Error("expected 2-of-3 to verify with 2 participating signatures")
+	}
+	if multiPub.VerifyBytes(msg, sign(0)) {
+		t.Error("expected 2-of-3 to reject a single participating signature")
+	}
+	if multiPub.VerifyBytes(msg, sign()) {
+		t.Error("expected 2-of-3 to reject zero participating signatures")
+	}
+}
+
+// TestPubKeyMultiZeroThresholdRejected guards against a PubKeyMulti whose
+// Threshold was never set (the uint zero value) being treated as
+// "0 signatures required" instead of invalid.
+func TestPubKeyMultiZeroThresholdRejected(t *testing.T) {
+	priv := PrivKeyEd25519(CRandBytes(32))
+	multiPub := PubKeyMulti{PubKeys: []PubKey{priv.PubKey()}}
+	sig := MultiSignature{Bitmap: []bool{false}, Sigs: nil}.Bytes()
+	if multiPub.VerifyBytes([]byte("hello"), sig) {
+		t.Error("expected a zero-value Threshold to reject every signature")
+	}
+}